@@ -0,0 +1,62 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"fmt"
+	"sort"
+)
+
+// buildChains groups files by GroupId and, within each group, orders them into
+// a full -> incr1 -> incr2 -> ... replay chain: the newest full backup with
+// ReadTs <= until, followed by each incremental whose LastBackupTS matches the
+// previous file's ReadTs. It returns an error if a link in a chain is missing.
+func buildChains(files []BackupFile, until uint64) (map[int][]BackupFile, error) {
+	byGroup := make(map[int][]BackupFile)
+	for _, f := range files {
+		if until > 0 && f.ReadTs > until {
+			continue
+		}
+		byGroup[f.GroupId] = append(byGroup[f.GroupId], f)
+	}
+
+	chains := make(map[int][]BackupFile)
+	for groupId, groupFiles := range byGroup {
+		sort.Slice(groupFiles, func(i, j int) bool { return groupFiles[i].ReadTs < groupFiles[j].ReadTs })
+
+		var fullIdx = -1
+		for i, f := range groupFiles {
+			if f.LastBackupTS == 0 {
+				fullIdx = i // keep the last (newest) full backup found
+			}
+		}
+		if fullIdx == -1 {
+			return nil, fmt.Errorf("no full backup found for group %d", groupId)
+		}
+
+		chain := []BackupFile{groupFiles[fullIdx]}
+		prev := groupFiles[fullIdx]
+		for _, f := range groupFiles[fullIdx+1:] {
+			if f.LastBackupTS != prev.ReadTs {
+				return nil, fmt.Errorf(
+					"missing link in backup chain for group %d: %q expects lastBackupTS %d, "+
+						"but the previous backup in the chain has backupTS %d",
+					groupId, f.Name, f.LastBackupTS, prev.ReadTs)
+			}
+			chain = append(chain, f)
+			prev = f
+		}
+		chains[groupId] = chain
+	}
+	return chains, nil
+}
@@ -0,0 +1,73 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import "testing"
+
+func TestBuildChainsFullAndIncrementals(t *testing.T) {
+	files := []BackupFile{
+		{Name: "r10-g1.backup", GroupId: 1, ReadTs: 10},
+		{Name: "r20-g1.backup", GroupId: 1, ReadTs: 20, LastBackupTS: 10},
+		{Name: "r30-g1.backup", GroupId: 1, ReadTs: 30, LastBackupTS: 20},
+	}
+
+	chains, err := buildChains(files, 0)
+	if err != nil {
+		t.Fatalf("buildChains: %v", err)
+	}
+	chain := chains[1]
+	if len(chain) != 3 {
+		t.Fatalf("expected a 3-file chain, got %+v", chain)
+	}
+	for i, want := range []string{"r10-g1.backup", "r20-g1.backup", "r30-g1.backup"} {
+		if chain[i].Name != want {
+			t.Fatalf("chain[%d] = %q, want %q", i, chain[i].Name, want)
+		}
+	}
+}
+
+func TestBuildChainsUntilStopsBeforeLaterFiles(t *testing.T) {
+	files := []BackupFile{
+		{Name: "r10-g1.backup", GroupId: 1, ReadTs: 10},
+		{Name: "r20-g1.backup", GroupId: 1, ReadTs: 20, LastBackupTS: 10},
+	}
+
+	chains, err := buildChains(files, 15)
+	if err != nil {
+		t.Fatalf("buildChains: %v", err)
+	}
+	if len(chains[1]) != 1 {
+		t.Fatalf("expected until=15 to exclude the r20 incremental, got %+v", chains[1])
+	}
+}
+
+func TestBuildChainsMissingLink(t *testing.T) {
+	files := []BackupFile{
+		{Name: "r10-g1.backup", GroupId: 1, ReadTs: 10},
+		{Name: "r30-g1.backup", GroupId: 1, ReadTs: 30, LastBackupTS: 20},
+	}
+
+	if _, err := buildChains(files, 0); err == nil {
+		t.Fatal("expected an error for a chain with a missing link")
+	}
+}
+
+func TestBuildChainsNoFullBackup(t *testing.T) {
+	files := []BackupFile{
+		{Name: "r20-g1.backup", GroupId: 1, ReadTs: 20, LastBackupTS: 10},
+	}
+
+	if _, err := buildChains(files, 0); err == nil {
+		t.Fatal("expected an error when no full backup anchors the chain")
+	}
+}
@@ -0,0 +1,80 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/dgraph-io/dgraph/ee/backup/storage"
+)
+
+// Checksum records the integrity fingerprint of a single backup object: the
+// SHA256 of its bytes, its byte length, and the number of badger keys it
+// contains. It is written to a "checksums.json" sidecar alongside each backup
+// object so restore can detect silent corruption (e.g. a truncated upload)
+// that a bare db.Load would otherwise swallow.
+type Checksum struct {
+	SHA256   string `json:"sha256"`
+	ByteLen  int64  `json:"byteLen"`
+	KeyCount int64  `json:"keyCount"`
+}
+
+// checksumName returns the checksum sidecar name for the given backup object
+// name, e.g. "r32-g2.backup" -> "r32-g2.checksums.json".
+func checksumName(backupName string) string {
+	return backupName[:len(backupName)-len(".backup")] + ".checksums.json"
+}
+
+// readChecksum reads and parses the checksum sidecar for the backup object
+// named backupName. It returns nil, nil if no sidecar exists, so that backup
+// sets written before checksums were introduced can still be restored.
+func readChecksum(s storage.ExternalStorage, backupName string) (*Checksum, error) {
+	r, err := s.Open(checksumName(backupName))
+	if err != nil {
+		if storage.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("while opening checksum for %q: %v", backupName, err)
+	}
+	defer r.Close()
+
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("while reading checksum for %q: %v", backupName, err)
+	}
+	var c Checksum
+	if err := json.Unmarshal(buf, &c); err != nil {
+		return nil, fmt.Errorf("while parsing checksum for %q: %v", backupName, err)
+	}
+	return &c, nil
+}
+
+// writeChecksum writes c as the checksum sidecar for the backup object named
+// backupName.
+func writeChecksum(s storage.ExternalStorage, backupName string, c *Checksum) error {
+	buf, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	w, err := s.Create(checksumName(backupName))
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buf); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
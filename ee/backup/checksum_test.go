@@ -0,0 +1,62 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadChecksumMissingSidecarIsNotAnError(t *testing.T) {
+	c, err := readChecksum(newFakeStorage(), "r10-g1.backup")
+	if err != nil {
+		t.Fatalf("expected no error for a missing sidecar, got %v", err)
+	}
+	if c != nil {
+		t.Fatalf("expected a nil checksum, got %+v", c)
+	}
+}
+
+func TestReadChecksumRealErrorPropagates(t *testing.T) {
+	s := newFakeStorage()
+	s.openErr = errors.New("connection reset")
+
+	if _, err := readChecksum(s, "r10-g1.backup"); err == nil {
+		t.Fatal("expected a transient storage error to propagate, not be swallowed")
+	}
+}
+
+func TestReadManifestRealErrorPropagates(t *testing.T) {
+	s := newFakeStorage()
+	s.openErr = errors.New("permission denied")
+
+	if _, err := readManifest(s, "r10-g1.backup"); err == nil {
+		t.Fatal("expected a permission error to propagate, not be treated as no manifest")
+	}
+}
+
+func TestChecksumRoundTrip(t *testing.T) {
+	s := newFakeStorage()
+	want := &Checksum{SHA256: "abc", ByteLen: 123, KeyCount: 7}
+	if err := writeChecksum(s, "r10-g1.backup", want); err != nil {
+		t.Fatalf("writeChecksum: %v", err)
+	}
+
+	got, err := readChecksum(s, "r10-g1.backup")
+	if err != nil {
+		t.Fatalf("readChecksum: %v", err)
+	}
+	if *got != *want {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
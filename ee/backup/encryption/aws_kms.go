@@ -0,0 +1,56 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package encryption
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+)
+
+// awsKMSProvider wraps data keys with an AWS KMS customer master key,
+// identified by its ARN (--encryption-kms=aws:arn:...).
+type awsKMSProvider struct {
+	client *kms.KMS
+	keyArn string
+}
+
+func newAWSKMSProvider(keyArn string) (KeyProvider, error) {
+	sess, err := session.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	return &awsKMSProvider{client: kms.New(sess), keyArn: keyArn}, nil
+}
+
+func (p *awsKMSProvider) WrapKey(dek []byte) ([]byte, error) {
+	out, err := p.client.Encrypt(&kms.EncryptInput{
+		KeyId:     aws.String(p.keyArn),
+		Plaintext: dek,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.CiphertextBlob, nil
+}
+
+func (p *awsKMSProvider) UnwrapKey(wrapped []byte) ([]byte, error) {
+	out, err := p.client.Decrypt(&kms.DecryptInput{
+		KeyId:          aws.String(p.keyArn),
+		CiphertextBlob: wrapped,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out.Plaintext, nil
+}
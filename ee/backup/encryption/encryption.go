@@ -0,0 +1,69 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+// Package encryption provides envelope encryption for backup objects: each
+// object gets its own random AES-256 data key (DEK), which is itself wrapped
+// by a master key sourced from a local key file or a cloud KMS. Only the
+// wrapped DEK is stored alongside the object, so the master key never leaves
+// its KeyProvider.
+package encryption
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KeyProvider wraps and unwraps per-object data encryption keys using a
+// master key it alone has access to.
+type KeyProvider interface {
+	// WrapKey encrypts dek with the master key and returns the wrapped form
+	// to store alongside the object.
+	WrapKey(dek []byte) ([]byte, error)
+
+	// UnwrapKey decrypts a DEK previously returned by WrapKey.
+	UnwrapKey(wrapped []byte) ([]byte, error)
+}
+
+// Parse builds the KeyProvider named by a restore or backup's encryption
+// flags: at most one of keyFile ("--encryption-key-file") or kms
+// ("--encryption-kms=aws:..." / "--encryption-kms=gcp:...") may be set. It
+// returns nil, nil if neither flag was given, meaning encryption is disabled.
+func Parse(keyFile, kms string) (KeyProvider, error) {
+	switch {
+	case keyFile != "" && kms != "":
+		return nil, fmt.Errorf("encryption: only one of --encryption-key-file or --encryption-kms may be set")
+	case keyFile != "":
+		return newFileKeyProvider(keyFile)
+	case kms != "":
+		provider, arn := splitKMSSpec(kms)
+		switch provider {
+		case "aws":
+			return newAWSKMSProvider(arn)
+		case "gcp":
+			return newGCPKMSProvider(arn)
+		default:
+			return nil, fmt.Errorf("encryption: unknown KMS provider %q, must be aws or gcp", provider)
+		}
+	default:
+		return nil, nil
+	}
+}
+
+// splitKMSSpec splits "aws:arn:..." or "gcp:projects/..." into its provider
+// and key-identifier halves.
+func splitKMSSpec(kms string) (provider, keyID string) {
+	parts := strings.SplitN(kms, ":", 2)
+	if len(parts) != 2 {
+		return kms, ""
+	}
+	return parts[0], parts[1]
+}
@@ -0,0 +1,67 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+	"io/ioutil"
+)
+
+// fileKeyProvider wraps data keys with a 32-byte AES-256 master key read from
+// a local file (--encryption-key-file).
+type fileKeyProvider struct {
+	aead cipher.AEAD
+}
+
+func newFileKeyProvider(path string) (KeyProvider, error) {
+	key, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("encryption: could not read key file %q: %v", path, err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption: key file %q must contain a 32-byte AES-256 key, got %d bytes",
+			path, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &fileKeyProvider{aead: aead}, nil
+}
+
+func (p *fileKeyProvider) WrapKey(dek []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	// Prepend the nonce so UnwrapKey can recover it; the master key never
+	// changes, only the DEK being wrapped does.
+	return p.aead.Seal(nonce, nonce, dek, nil), nil
+}
+
+func (p *fileKeyProvider) UnwrapKey(wrapped []byte) ([]byte, error) {
+	n := p.aead.NonceSize()
+	if len(wrapped) < n {
+		return nil, fmt.Errorf("encryption: wrapped key too short")
+	}
+	nonce, ciphertext := wrapped[:n], wrapped[n:]
+	return p.aead.Open(nil, nonce, ciphertext, nil)
+}
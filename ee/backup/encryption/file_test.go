@@ -0,0 +1,108 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func newTestKeyFile(t *testing.T) string {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+	f, err := ioutil.TempFile("", "master-key")
+	if err != nil {
+		t.Fatalf("TempFile: %v", err)
+	}
+	if _, err := f.Write(key); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	return f.Name()
+}
+
+func TestFileKeyProviderWrapUnwrapRoundTrip(t *testing.T) {
+	p, err := newFileKeyProvider(newTestKeyFile(t))
+	if err != nil {
+		t.Fatalf("newFileKeyProvider: %v", err)
+	}
+
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+
+	wrapped, err := p.WrapKey(dek)
+	if err != nil {
+		t.Fatalf("WrapKey: %v", err)
+	}
+	if bytes.Equal(wrapped, dek) {
+		t.Fatal("WrapKey returned the DEK unmodified")
+	}
+
+	unwrapped, err := p.UnwrapKey(wrapped)
+	if err != nil {
+		t.Fatalf("UnwrapKey: %v", err)
+	}
+	if !bytes.Equal(unwrapped, dek) {
+		t.Fatalf("got %x, want original dek %x", unwrapped, dek)
+	}
+}
+
+func TestFileKeyProviderUnwrapRejectsTamperedKey(t *testing.T) {
+	p, err := newFileKeyProvider(newTestKeyFile(t))
+	if err != nil {
+		t.Fatalf("newFileKeyProvider: %v", err)
+	}
+
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		t.Fatalf("rand.Read: %v", err)
+	}
+	wrapped, err := p.WrapKey(dek)
+	if err != nil {
+		t.Fatalf("WrapKey: %v", err)
+	}
+	wrapped[len(wrapped)-1] ^= 0xFF
+
+	if _, err := p.UnwrapKey(wrapped); err == nil {
+		t.Fatal("expected UnwrapKey to reject a tampered wrapped key")
+	}
+}
+
+func TestFileKeyProviderRejectsWrongSizeKeyFile(t *testing.T) {
+	f, err := ioutil.TempFile("", "master-key")
+	if err != nil {
+		t.Fatalf("TempFile: %v", err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write([]byte("too short")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if _, err := newFileKeyProvider(f.Name()); err == nil {
+		t.Fatal("expected newFileKeyProvider to reject a non-32-byte key file")
+	}
+}
@@ -0,0 +1,60 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package encryption
+
+import (
+	"context"
+
+	kms "cloud.google.com/go/kms/apiv1"
+	kmspb "google.golang.org/genproto/googleapis/cloud/kms/v1"
+)
+
+// gcpKMSProvider wraps data keys with a GCP Cloud KMS key, identified by its
+// resource name (--encryption-kms=gcp:projects/.../keys/...).
+type gcpKMSProvider struct {
+	client  *kms.KeyManagementClient
+	keyName string
+}
+
+func newGCPKMSProvider(keyName string) (KeyProvider, error) {
+	ctx := context.Background()
+	client, err := kms.NewKeyManagementClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &gcpKMSProvider{client: client, keyName: keyName}, nil
+}
+
+func (p *gcpKMSProvider) WrapKey(dek []byte) ([]byte, error) {
+	ctx := context.Background()
+	resp, err := p.client.Encrypt(ctx, &kmspb.EncryptRequest{
+		Name:      p.keyName,
+		Plaintext: dek,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Ciphertext, nil
+}
+
+func (p *gcpKMSProvider) UnwrapKey(wrapped []byte) ([]byte, error) {
+	ctx := context.Background()
+	resp, err := p.client.Decrypt(ctx, &kmspb.DecryptRequest{
+		Name:       p.keyName,
+		Ciphertext: wrapped,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Plaintext, nil
+}
@@ -0,0 +1,62 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"fmt"
+	"sync"
+)
+
+// restoreEncryptionState tracks, across every file restored in a single
+// `dgraph restore` run (possibly from multiple goroutines, one per group),
+// whether the set as a whole is encrypted or plaintext. Restore rejects a mix
+// of the two unless allowPlaintext is set.
+type restoreEncryptionState struct {
+	mu             sync.Mutex
+	sawEncrypted   *bool
+	allowPlaintext bool
+}
+
+func newRestoreEncryptionState(allowPlaintext bool) *restoreEncryptionState {
+	return &restoreEncryptionState{allowPlaintext: allowPlaintext}
+}
+
+// observe records whether one more file was encrypted, and returns an error
+// if that's inconsistent with every file observed so far.
+func (s *restoreEncryptionState) observe(name string, encrypted bool) error {
+	if s.allowPlaintext {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sawEncrypted == nil {
+		s.sawEncrypted = &encrypted
+		return nil
+	}
+	if *s.sawEncrypted != encrypted {
+		return fmt.Errorf(
+			"backup: %q is %s but earlier files in this restore were %s; "+
+				"pass --allow-plaintext to restore a mixed set",
+			name, encryptedLabel(encrypted), encryptedLabel(*s.sawEncrypted))
+	}
+	return nil
+}
+
+func encryptedLabel(encrypted bool) string {
+	if encrypted {
+		return "encrypted"
+	}
+	return "plaintext"
+}
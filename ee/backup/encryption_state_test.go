@@ -0,0 +1,55 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import "testing"
+
+func TestRestoreEncryptionStateAllEncryptedIsFine(t *testing.T) {
+	s := newRestoreEncryptionState(false)
+	if err := s.observe("r10-g1.backup", true); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	if err := s.observe("r10-g2.backup", true); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+}
+
+func TestRestoreEncryptionStateAllPlaintextIsFine(t *testing.T) {
+	s := newRestoreEncryptionState(false)
+	if err := s.observe("r10-g1.backup", false); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	if err := s.observe("r10-g2.backup", false); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+}
+
+func TestRestoreEncryptionStateRejectsMixedSet(t *testing.T) {
+	s := newRestoreEncryptionState(false)
+	if err := s.observe("r10-g1.backup", true); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	if err := s.observe("r10-g2.backup", false); err == nil {
+		t.Fatal("expected observe to reject a mix of encrypted and plaintext files")
+	}
+}
+
+func TestRestoreEncryptionStateAllowPlaintextSkipsCheck(t *testing.T) {
+	s := newRestoreEncryptionState(true)
+	if err := s.observe("r10-g1.backup", true); err != nil {
+		t.Fatalf("observe: %v", err)
+	}
+	if err := s.observe("r10-g2.backup", false); err != nil {
+		t.Fatalf("expected --allow-plaintext to permit a mixed set, got %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/dgraph-io/dgraph/ee/backup/storage"
+)
+
+// fakeStorage is an in-memory storage.ExternalStorage used by tests that
+// exercise manifest/checksum/LATEST sidecar logic without a real backend.
+type fakeStorage struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	// openErr, if set, is returned by Open for every call instead of the
+	// usual not-exist error, standing in for a transient or permission
+	// error from a real backend.
+	openErr error
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{objects: make(map[string][]byte)}
+}
+
+func (f *fakeStorage) Open(name string) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.openErr != nil {
+		return nil, f.openErr
+	}
+	buf, ok := f.objects[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return ioutil.NopCloser(bytes.NewReader(buf)), nil
+}
+
+type fakeWriter struct {
+	bytes.Buffer
+	commit func([]byte)
+}
+
+func (w *fakeWriter) Close() error {
+	w.commit(w.Bytes())
+	return nil
+}
+
+func (f *fakeStorage) Create(name string) (io.WriteCloser, error) {
+	return &fakeWriter{commit: func(buf []byte) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.objects[name] = append([]byte(nil), buf...)
+	}}, nil
+}
+
+func (f *fakeStorage) List(prefix string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for name := range f.objects {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (f *fakeStorage) Delete(name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.objects, name)
+	return nil
+}
+
+var _ storage.ExternalStorage = (*fakeStorage)(nil)
@@ -0,0 +1,74 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/dgraph-io/badger/pb"
+)
+
+// writeFrame length-prefixes buf with an 8-byte big-endian length and writes
+// both to w. This is the framing every chunk of a backup stream uses,
+// whether it carries a marshaled pb.KVList or an encrypted chunk wrapping
+// one.
+func writeFrame(w io.Writer, buf []byte) (int64, error) {
+	var lenBuf [8]byte
+	binary.BigEndian.PutUint64(lenBuf[:], uint64(len(buf)))
+	if _, err := w.Write(lenBuf[:]); err != nil {
+		return 0, err
+	}
+	n, err := w.Write(buf)
+	return int64(len(lenBuf)) + int64(n), err
+}
+
+// readFrame reads one length-prefixed chunk from r, as written by writeFrame.
+// It returns io.EOF once r is exhausted.
+func readFrame(r io.Reader) ([]byte, error) {
+	var lenBuf [8]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, binary.BigEndian.Uint64(lenBuf[:]))
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// writeKVList marshals list and writes it to w as a single frame. This is the
+// framing badger.DB.Load expects when reading a backup stream back in.
+func writeKVList(w io.Writer, list *pb.KVList) (int64, error) {
+	buf, err := list.Marshal()
+	if err != nil {
+		return 0, err
+	}
+	return writeFrame(w, buf)
+}
+
+// readKVList reads one frame from r, as written by writeKVList, and parses it
+// as a pb.KVList. It returns io.EOF once r is exhausted.
+func readKVList(r io.Reader) (*pb.KVList, error) {
+	buf, err := readFrame(r)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &pb.KVList{}
+	if err := list.Unmarshal(buf); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
@@ -0,0 +1,119 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+
+	"github.com/dgraph-io/dgraph/ee/backup/storage"
+	"golang.org/x/sync/errgroup"
+)
+
+// fileNameRegex matches backup object names of the form "r<readTs>-g<groupId>.backup",
+// as written by the backup writer path.
+var fileNameRegex = regexp.MustCompile(`^r(\d+)-g(\d+)\.backup$`)
+
+// ChainEntry is one file in a group's full -> incr1 -> incr2 -> ... replay
+// chain, as passed to a groupLoadFunc.
+type ChainEntry struct {
+	Name string
+	// ReadTs is this entry's own read timestamp, and PrevReadTs is the read
+	// timestamp of the previous entry in the chain (0 for the full backup).
+	// Together they let a checksum check isolate the keys this entry alone
+	// contributed, rather than the chain's cumulative total.
+	ReadTs     uint64
+	PrevReadTs uint64
+	IsFull     bool
+	open       func() (io.ReadCloser, error)
+	storage    storage.ExternalStorage
+}
+
+// Open returns a reader over this entry's backup stream. It is the caller's
+// responsibility to close it.
+func (c ChainEntry) Open() (io.ReadCloser, error) {
+	return c.open()
+}
+
+// Checksum returns the checksum sidecar recorded for this entry when it was
+// written, or nil if none was recorded.
+func (c ChainEntry) Checksum() (*Checksum, error) {
+	return readChecksum(c.storage, c.Name)
+}
+
+// groupLoadFunc is called once per node group found at location, with its
+// replay chain ordered full-backup-first. It is responsible for opening the
+// group's DB and applying each entry to it in order.
+type groupLoadFunc func(groupId int, chain []ChainEntry) error
+
+// Load finds the backup chain for each node group at location, using locator
+// to enumerate the available files (incremental backups with a read timestamp
+// before since are left out; full backups are kept regardless of since) and
+// keeping only files with a read timestamp no later than until (0 meaning no
+// upper bound), and calls fn once per group with that group's ordered chain. Up to parallel groups are
+// restored concurrently; if parallel is less than 1 it is treated as 1.
+func Load(location string, since, until uint64, locator Locator, parallel int, fn groupLoadFunc) error {
+	if locator == nil {
+		locator = ScanLocator{}
+	}
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	backend, err := storage.ParseBackend(location)
+	if err != nil {
+		return err
+	}
+
+	files, err := locator.Enumerate(backend.Storage, since)
+	if err != nil {
+		return fmt.Errorf("while listing %q: %v", location, err)
+	}
+
+	chains, err := buildChains(files, until)
+	if err != nil {
+		return err
+	}
+
+	var eg errgroup.Group
+	sem := make(chan struct{}, parallel)
+	for groupId, chain := range chains {
+		groupId, chain := groupId, chain
+		sem <- struct{}{}
+		eg.Go(func() error {
+			defer func() { <-sem }()
+			entries := make([]ChainEntry, len(chain))
+			for i, f := range chain {
+				f := f
+				var prevReadTs uint64
+				if i > 0 {
+					prevReadTs = chain[i-1].ReadTs
+				}
+				entries[i] = ChainEntry{
+					Name:       f.Name,
+					ReadTs:     f.ReadTs,
+					PrevReadTs: prevReadTs,
+					IsFull:     i == 0,
+					open:       func() (io.ReadCloser, error) { return backend.Storage.Open(f.Name) },
+					storage:    backend.Storage,
+				}
+			}
+			if err := fn(groupId, entries); err != nil {
+				return fmt.Errorf("while restoring group %d: %v", groupId, err)
+			}
+			return nil
+		})
+	}
+	return eg.Wait()
+}
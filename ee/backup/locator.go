@@ -0,0 +1,222 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"sync"
+
+	"github.com/dgraph-io/dgraph/ee/backup/storage"
+)
+
+// BackupFile describes a single node group's backup object as discovered by a
+// Locator. LastBackupTS is 0 for a full backup, or the BackupTS of the backup
+// this one is incremental against.
+type BackupFile struct {
+	Name         string
+	GroupId      int
+	ReadTs       uint64
+	LastBackupTS uint64
+}
+
+// Locator finds the set of backup files at location that should be restored,
+// filtering out incremental backups with a read timestamp before since. Full
+// backups are kept regardless of since, as they anchor their group's chain.
+type Locator interface {
+	Enumerate(s storage.ExternalStorage, since uint64) ([]BackupFile, error)
+}
+
+// ScanLocator reproduces the original restore behavior: it lists every object
+// at location and parses the group ID and read timestamp out of each
+// filename that matches the "r<readTs>-g<groupId>.backup" convention.
+type ScanLocator struct{}
+
+func (ScanLocator) Enumerate(s storage.ExternalStorage, since uint64) ([]BackupFile, error) {
+	names, err := s.List("")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []BackupFile
+	for _, name := range names {
+		matches := fileNameRegex.FindStringSubmatch(name)
+		if matches == nil {
+			continue
+		}
+		readTs, err := strconv.ParseUint(matches[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		groupId, err := strconv.Atoi(matches[2])
+		if err != nil {
+			return nil, fmt.Errorf("while parsing group id from %q: %v", name, err)
+		}
+
+		file := BackupFile{Name: name, GroupId: groupId, ReadTs: readTs}
+		m, err := readManifest(s, name)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			file.LastBackupTS = m.LastBackupTS
+		}
+
+		// Only prune incrementals against since; a full backup anchors its
+		// group's replay chain and must survive regardless of since, or
+		// chain-based restore breaks whenever since is past the full
+		// backup's own read timestamp.
+		if file.LastBackupTS != 0 && readTs < since {
+			continue
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// latestManifest is the JSON document written to the "LATEST" object,
+// listing the canonical backup set a PointerLocator should restore.
+type latestManifest struct {
+	Files []BackupFile
+}
+
+// latestName is the object name Writer.Close publishes to and PointerLocator
+// reads from.
+const latestName = "LATEST"
+
+// readLatest reads and parses the "LATEST" manifest object. It returns a
+// zero-value manifest, nil if no object has been published yet, so the first
+// backup in a location can still publish one.
+func readLatest(s storage.ExternalStorage) (*latestManifest, error) {
+	r, err := s.Open(latestName)
+	if err != nil {
+		if storage.IsNotExist(err) {
+			return &latestManifest{}, nil
+		}
+		return nil, fmt.Errorf("while opening %s manifest: %v", latestName, err)
+	}
+	defer r.Close()
+
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("while reading %s manifest: %v", latestName, err)
+	}
+	var m latestManifest
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, fmt.Errorf("while parsing %s manifest: %v", latestName, err)
+	}
+	return &m, nil
+}
+
+// latestLocks serializes publishLatest's read-modify-write of the "LATEST"
+// object per destination. A backup run closes every node group's Writer
+// concurrently, and each Close publishes to the same object, so without this
+// lock two overlapping publishes can each read the manifest before the other
+// writes it back, and the second Create silently drops the first group's
+// entry. storage.ExternalStorage has no conditional-write primitive to do
+// this as a true compare-and-swap across backends, so instead every
+// publishLatest call for a given storage instance (all of one run's group
+// writers share one) goes through the same in-process mutex.
+var (
+	latestLocksMu sync.Mutex
+	latestLocks   = map[storage.ExternalStorage]*sync.Mutex{}
+)
+
+func latestLock(s storage.ExternalStorage) *sync.Mutex {
+	latestLocksMu.Lock()
+	defer latestLocksMu.Unlock()
+	mu, ok := latestLocks[s]
+	if !ok {
+		mu = &sync.Mutex{}
+		latestLocks[s] = mu
+	}
+	return mu
+}
+
+// publishLatest adds file to the canonical backup set recorded in the
+// "LATEST" object, replacing any existing entry with the same name, and
+// rewrites the object. Writer.Close calls this after its own manifest and
+// checksum sidecars are written, so PointerLocator always sees a backup set
+// whose sidecars are already in place.
+func publishLatest(s storage.ExternalStorage, file BackupFile) error {
+	mu := latestLock(s)
+	mu.Lock()
+	defer mu.Unlock()
+
+	m, err := readLatest(s)
+	if err != nil {
+		return err
+	}
+
+	files := m.Files[:0]
+	for _, f := range m.Files {
+		if f.Name != file.Name {
+			files = append(files, f)
+		}
+	}
+	m.Files = append(files, file)
+
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	w, err := s.Create(latestName)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buf); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// PointerLocator reads a "LATEST" manifest object that lists the canonical
+// backup set, rather than re-deriving it from a directory scan. This lets
+// backup writers evolve the on-disk layout without restore needing to follow.
+type PointerLocator struct{}
+
+func (PointerLocator) Enumerate(s storage.ExternalStorage, since uint64) ([]BackupFile, error) {
+	manifest, err := readLatest(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(manifest.Files) == 0 {
+		return nil, fmt.Errorf("while reading %s manifest: no backups have been published", latestName)
+	}
+
+	var files []BackupFile
+	for _, f := range manifest.Files {
+		// As in ScanLocator, a full backup must survive since filtering so it
+		// can still anchor its group's replay chain.
+		if f.LastBackupTS != 0 && f.ReadTs < since {
+			continue
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+// getLocator returns the Locator registered under name.
+func getLocator(name string) (Locator, error) {
+	switch name {
+	case "", "scan":
+		return ScanLocator{}, nil
+	case "pointer":
+		return PointerLocator{}, nil
+	default:
+		return nil, fmt.Errorf("unknown locator %q, must be one of: scan, pointer", name)
+	}
+}
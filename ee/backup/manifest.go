@@ -0,0 +1,82 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/dgraph-io/dgraph/ee/backup/storage"
+)
+
+// Manifest records everything restore needs to place a single backup object in
+// its chain: the read timestamp it was taken at, the read timestamp of the
+// backup it is incremental against (0 for a full backup), and the group and
+// files it covers. One manifest is written alongside each backup object, named
+// by replacing the ".backup" suffix with ".manifest.json".
+type Manifest struct {
+	BackupTS     uint64   `json:"backupTS"`
+	LastBackupTS uint64   `json:"lastBackupTS"`
+	GroupId      int      `json:"groupId"`
+	Files        []string `json:"files"`
+}
+
+// manifestName returns the manifest object name for the given backup object
+// name, e.g. "r32-g2.backup" -> "r32-g2.manifest.json".
+func manifestName(backupName string) string {
+	return backupName[:len(backupName)-len(".backup")] + ".manifest.json"
+}
+
+// readManifest reads and parses the manifest sidecar for the backup object
+// named backupName. It returns nil, nil if no sidecar exists, so that backup
+// sets written before manifests were introduced can still be restored as a
+// single full backup.
+func readManifest(s storage.ExternalStorage, backupName string) (*Manifest, error) {
+	r, err := s.Open(manifestName(backupName))
+	if err != nil {
+		if storage.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("while opening manifest for %q: %v", backupName, err)
+	}
+	defer r.Close()
+
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("while reading manifest for %q: %v", backupName, err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, fmt.Errorf("while parsing manifest for %q: %v", backupName, err)
+	}
+	return &m, nil
+}
+
+// writeManifest writes m as the manifest sidecar for the backup object named
+// backupName.
+func writeManifest(s storage.ExternalStorage, backupName string, m *Manifest) error {
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	w, err := s.Create(manifestName(backupName))
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buf); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,78 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// progressReporter prints restore throughput (MB/s and keys/s) every few
+// seconds until stopped, so a multi-hundred-GB restore shows visible signs of
+// life instead of sitting quiet until it's done.
+type progressReporter struct {
+	groupId int
+	bytes   int64
+	keys    int64
+	start   time.Time
+	stopCh  chan struct{}
+	stopped chan struct{}
+}
+
+func newProgressReporter(groupId int, interval time.Duration) *progressReporter {
+	p := &progressReporter{
+		groupId: groupId,
+		start:   time.Now(),
+		stopCh:  make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go p.run(interval)
+	return p
+}
+
+// addBytes and addKeys are safe to call concurrently from the writer
+// goroutines that are applying restored data.
+func (p *progressReporter) addBytes(n int64) { atomic.AddInt64(&p.bytes, n) }
+func (p *progressReporter) addKeys(n int64)  { atomic.AddInt64(&p.keys, n) }
+
+func (p *progressReporter) run(interval time.Duration) {
+	defer close(p.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.report()
+		case <-p.stopCh:
+			return
+		}
+	}
+}
+
+func (p *progressReporter) report() {
+	elapsed := time.Since(p.start).Seconds()
+	if elapsed == 0 {
+		return
+	}
+	mbps := float64(atomic.LoadInt64(&p.bytes)) / (1 << 20) / elapsed
+	kps := float64(atomic.LoadInt64(&p.keys)) / elapsed
+	fmt.Printf("--- groupId: %d, restored %.1f MB/s, %.0f keys/s\n", p.groupId, mbps, kps)
+}
+
+// Stop halts the periodic reporting and prints one final summary line.
+func (p *progressReporter) Stop() {
+	close(p.stopCh)
+	<-p.stopped
+	p.report()
+}
@@ -13,15 +13,20 @@
 package backup
 
 import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
 	"fmt"
 	"io"
 	"math"
 	"os"
 	"path/filepath"
+	"runtime"
 	"time"
 
 	"github.com/dgraph-io/badger"
 	"github.com/dgraph-io/badger/options"
+	"github.com/dgraph-io/dgraph/ee/backup/encryption"
 	"github.com/dgraph-io/dgraph/x"
 	"github.com/spf13/cobra"
 )
@@ -29,9 +34,19 @@ import (
 var Restore x.SubCommand
 
 var opt struct {
-	location string
-	pdir     string
-	since    uint64
+	location           string
+	pdir               string
+	since              uint64
+	until              uint64
+	parallel           int
+	locator            string
+	checksum           bool
+	encryptionKeyFile  string
+	encryptionKMS      string
+	allowPlaintext     bool
+	restoreConcurrency int
+	maxPendingWrites   int
+	valueThreshold     int64
 }
 
 func init() {
@@ -53,21 +68,51 @@ Source URI formats:
   /[path]?[args] (only for local or NFS)
 
 Source URI parts:
-  scheme - service handler, one of: "s3", "minio", "file"
+  scheme - service handler, one of: "s3", "minio", "gs", "azure", "hdfs", "file"
     host - remote address. ex: "dgraph.s3.amazonaws.com"
     path - directory, bucket or container at target. ex: "/dgraph/backups/"
-    args - specific arguments that are ok to appear in logs.
+    args - backend-specific arguments, e.g. "?endpoint=", "?region=", "?sse=",
+           "?storage-class=" or "?credentials-file=". These are ok to appear in logs.
 
 The --posting flag sets the posting list parent dir to store the loaded backup files.
 
-The --since flag will try to restore from a specific read timestamp. Each backup file has
-the read timestamp in their name. If this flag is not used, the restore starts from the
-latest version.
+The --since flag prunes incremental backups taken before the given read timestamp out of
+the restore set. Each group's full backup is always kept regardless of --since, since it
+anchors that group's replay chain. If this flag is not used, every backup found is
+restored.
+
+The --until flag restores up to and including a specific read timestamp, for point-in-time
+recovery. Restore replays each group's chain of backups in order: the newest full backup
+with a read timestamp at or before --until, followed by every incremental backup that
+continues it, matching each incremental's lastBackupTS to the previous file's read
+timestamp. Restore refuses to proceed if a link in that chain is missing.
 
 Dgraph backup creates a unique backup object for each node group, and restore will create
 a posting directory 'p' matching the backup group ID. Such that a backup file
 named '.../r32-g2.backup' will be loaded to posting dir 'p2'.
 
+The --parallel flag controls how many groups are restored concurrently.
+
+The --locator flag selects how the set of backup files to restore is found:
+"scan" (the default) lists and parses the files at --location directly,
+while "pointer" reads a LATEST manifest object describing the canonical set.
+
+The --checksum flag (on by default) verifies, for every file that recorded a checksum
+sidecar at backup time, that the bytes read during restore hash to the same SHA256 and
+that the loaded DB has at least as many keys as were backed up. Restore fails with a
+clear per-group error on any mismatch, rather than silently loading truncated data.
+
+Encrypted backups are restored transparently: restore detects the per-object header on
+each file and unwraps its data key using whichever of --encryption-key-file or
+--encryption-kms is given, which must match what the backup was taken with. A restore set
+that mixes encrypted and plaintext files is rejected unless --allow-plaintext is passed.
+
+Each file is streamed into its group's DB through a badger.StreamWriter rather than
+db.Load, so decode and disk writes happen on --restore-concurrency goroutines instead of
+one. --restore-max-pending-writes bounds how many decoded batches may queue per goroutine,
+and --restore-value-threshold sets the badger value-log threshold used while restoring.
+Throughput (MB/s and keys/s) is printed periodically per group while this runs.
+
 Usage examples:
 
 # Restore from local dir or NFS mount:
@@ -79,6 +124,9 @@ $ dgraph restore -p /var/db/dgraph -l s3://s3.us-west-2.amazonaws.com/srfrog/dgr
 # Restore since read timestamp 20001:
 $ dgraph restore -since 20001 -p /var/db/dgraph -l s3://s3.us-west-2.amazonaws.com/srfrog/dgraph
 
+# Point-in-time restore through read timestamp 50000:
+$ dgraph restore -until 50000 -p /var/db/dgraph -l s3://s3.us-west-2.amazonaws.com/srfrog/dgraph
+
 		`,
 		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
@@ -97,6 +145,26 @@ $ dgraph restore -since 20001 -p /var/db/dgraph -l s3://s3.us-west-2.amazonaws.c
 		"Directory where posting lists are stored (required).")
 	flag.Uint64Var(&opt.since, "since", 0,
 		"Starting version for partial restore")
+	flag.Uint64Var(&opt.until, "until", 0,
+		"Ending version for point-in-time restore; 0 restores through the latest backup")
+	flag.IntVar(&opt.parallel, "parallel", runtime.NumCPU(),
+		"Number of groups to restore in parallel")
+	flag.StringVar(&opt.locator, "locator", "scan",
+		"Strategy used to find backup files: scan or pointer")
+	flag.BoolVar(&opt.checksum, "checksum", true,
+		"Verify each restored file's SHA256 and key count against its backup-time checksum")
+	flag.StringVar(&opt.encryptionKeyFile, "encryption-key-file", "",
+		"Path to a local file holding the AES-256 master key backups were encrypted with")
+	flag.StringVar(&opt.encryptionKMS, "encryption-kms", "",
+		`KMS key backups were encrypted with, as "aws:<arn>" or "gcp:<resource name>"`)
+	flag.BoolVar(&opt.allowPlaintext, "allow-plaintext", false,
+		"Allow restoring a set that mixes encrypted and plaintext backup files")
+	flag.IntVar(&opt.restoreConcurrency, "restore-concurrency", runtime.NumCPU(),
+		"Number of goroutines decoding and writing each group's backup stream")
+	flag.IntVar(&opt.maxPendingWrites, "restore-max-pending-writes", 1000,
+		"Max batches queued per restore-concurrency goroutine before the decoder blocks")
+	flag.Int64Var(&opt.valueThreshold, "restore-value-threshold", 1<<10,
+		"Badger value-log threshold to use for the restored DBs")
 	_ = Restore.Cmd.MarkFlagRequired("postings")
 	_ = Restore.Cmd.MarkFlagRequired("location")
 }
@@ -112,19 +180,39 @@ func run() (err error) {
 		}
 	}()
 
-	return runRestore(opt.pdir, opt.location, opt.since)
+	return runRestore(opt.pdir, opt.location, opt.since, opt.until, opt.parallel, opt.locator, opt.checksum,
+		opt.encryptionKeyFile, opt.encryptionKMS, opt.allowPlaintext, streamRestoreOptions{
+			Concurrency:      opt.restoreConcurrency,
+			MaxPendingWrites: opt.maxPendingWrites,
+			ValueThreshold:   opt.valueThreshold,
+		})
 }
 
-// runRestore calls badger.Load and tries to load data into a new DB.
-func runRestore(pdir, location string, since uint64) error {
-	// Scan location for backup files and load them. Each file represents a node group,
-	// and we create a new p dir for each.
-	return Load(location, since, func(r io.Reader, groupId int) error {
-		fmt.Printf("--- Restoring groupId: %d, since: %d\n", groupId, since)
+// runRestore opens each group's badger DB once and streams its backup chain
+// into it via streamLoad, which fans the decode and write path out across
+// restoreConcurrency goroutines instead of running them on badger's single
+// db.Load path.
+func runRestore(
+	pdir, location string, since, until uint64, parallel int, locatorName string, checksum bool,
+	encryptionKeyFile, encryptionKMS string, allowPlaintext bool, streamOpts streamRestoreOptions,
+) error {
+	locator, err := getLocator(locatorName)
+	if err != nil {
+		return err
+	}
+	keyProvider, err := encryption.Parse(encryptionKeyFile, encryptionKMS)
+	if err != nil {
+		return err
+	}
+	encState := newRestoreEncryptionState(allowPlaintext)
+
+	// Find location's backup chains via locator and replay them. Each chain restores one
+	// node group into its own p dir; up to parallel groups load concurrently.
+	return Load(location, since, until, locator, parallel, func(groupId int, chain []ChainEntry) error {
 		bo := badger.DefaultOptions
 		bo.SyncWrites = true
 		bo.TableLoadingMode = options.MemoryMap
-		bo.ValueThreshold = 1 << 10
+		bo.ValueThreshold = streamOpts.ValueThreshold
 		bo.NumVersionsToKeep = math.MaxInt32
 		bo.Dir = filepath.Join(pdir, fmt.Sprintf("p%d", groupId))
 		bo.ValueDir = bo.Dir
@@ -134,6 +222,84 @@ func runRestore(pdir, location string, since uint64) error {
 		}
 		defer db.Close()
 		fmt.Println("--- Creating new db:", bo.Dir)
-		return db.Load(r)
+
+		progress := newProgressReporter(groupId, 5*time.Second)
+		defer progress.Stop()
+
+		for _, entry := range chain {
+			fmt.Printf("--- Restoring groupId: %d, file: %s, full: %v\n",
+				groupId, entry.Name, entry.IsFull)
+			r, err := entry.Open()
+			if err != nil {
+				return err
+			}
+
+			br := bufio.NewReader(r)
+			hr := newHashingReader(br)
+			loadR, err := maybeDecrypt(hr, br, entry.Name, keyProvider, encState)
+			if err != nil {
+				_ = r.Close()
+				return err
+			}
+
+			err = streamLoad(db, loadR, streamOpts, progress)
+			_ = r.Close()
+			if err != nil {
+				return fmt.Errorf("while applying %q: %v", entry.Name, err)
+			}
+
+			if checksum {
+				want, err := entry.Checksum()
+				if err != nil {
+					return err
+				}
+				if err := verifyChecksum(want, hr, db, entry); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
 	})
 }
+
+// maybeDecrypt detects whether hr's underlying stream is an encrypted backup
+// object and, if so, unwraps its data key with keyProvider and wraps hr in a
+// decrypting reader. It also records the file's encrypted/plaintext status in
+// encState, failing if that's inconsistent with the rest of the restore set.
+func maybeDecrypt(
+	hr *hashingReader, br *bufio.Reader, name string, keyProvider encryption.KeyProvider,
+	encState *restoreEncryptionState,
+) (io.Reader, error) {
+	encrypted, err := peekEncrypted(br)
+	if err != nil {
+		return nil, fmt.Errorf("while inspecting %q: %v", name, err)
+	}
+	if err := encState.observe(name, encrypted); err != nil {
+		return nil, err
+	}
+	if !encrypted {
+		return hr, nil
+	}
+	if keyProvider == nil {
+		return nil, fmt.Errorf(
+			"%q is encrypted but no --encryption-key-file or --encryption-kms was given", name)
+	}
+
+	header, err := readHeader(hr)
+	if err != nil {
+		return nil, fmt.Errorf("while reading header of %q: %v", name, err)
+	}
+	dek, err := keyProvider.UnwrapKey(header.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("while unwrapping data key for %q: %v", name, err)
+	}
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return newAEADChunkReader(hr, aead, header.BaseNonce), nil
+}
@@ -0,0 +1,75 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileStorage implements ExternalStorage against a local or NFS-mounted
+// directory. It is also used as the fallback when a URI has no scheme.
+type fileStorage struct {
+	dir string
+}
+
+func newFileStorage(b *Backend) (ExternalStorage, error) {
+	dir := "/" + b.Path
+	if b.Host != "" {
+		dir = filepath.Join(b.Host, b.Path)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &fileStorage{dir: dir}, nil
+}
+
+func (f *fileStorage) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(f.dir, name))
+}
+
+func (f *fileStorage) Create(name string) (io.WriteCloser, error) {
+	path := filepath.Join(f.dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(path)
+}
+
+func (f *fileStorage) List(prefix string) ([]string, error) {
+	entries, err := ioutil.ReadDir(f.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if prefix == "" || strings.HasPrefix(e.Name(), prefix) {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (f *fileStorage) Delete(name string) error {
+	err := os.Remove(filepath.Join(f.dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// gcsStorage implements ExternalStorage against Google Cloud Storage. The
+// bucket is the first path segment of the gs:// URI and the remainder is used
+// as a key prefix, e.g. gs://my-bucket/dgraph/backups.
+type gcsStorage struct {
+	client *storage.Client
+	bucket string
+	prefix string
+}
+
+func newGCSStorage(b *Backend) (ExternalStorage, error) {
+	ctx := context.Background()
+	var opts []option.ClientOption
+	if cf := b.Args.Get("credentials-file"); cf != "" {
+		opts = append(opts, option.WithCredentialsFile(cf))
+	}
+
+	client, err := storage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("storage: could not create gcs client: %v", err)
+	}
+
+	bucket := b.Host
+	prefix := b.Path
+	if bucket == "" {
+		parts := splitBucketPrefix(b.Path)
+		bucket, prefix = parts[0], parts[1]
+	}
+	if bucket == "" {
+		return nil, fmt.Errorf("storage: gs backend requires a bucket")
+	}
+	return &gcsStorage{client: client, bucket: bucket, prefix: prefix}, nil
+}
+
+func (g *gcsStorage) Open(name string) (io.ReadCloser, error) {
+	ctx := context.Background()
+	return g.client.Bucket(g.bucket).Object(joinPrefix(g.prefix, name)).NewReader(ctx)
+}
+
+func (g *gcsStorage) Create(name string) (io.WriteCloser, error) {
+	ctx := context.Background()
+	return g.client.Bucket(g.bucket).Object(joinPrefix(g.prefix, name)).NewWriter(ctx), nil
+}
+
+func (g *gcsStorage) List(prefix string) ([]string, error) {
+	ctx := context.Background()
+	it := g.client.Bucket(g.bucket).Objects(ctx, &storage.Query{Prefix: joinPrefix(g.prefix, prefix)})
+	var names []string
+	for {
+		obj, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, strings.TrimPrefix(obj.Name, joinPrefix(g.prefix, "")))
+	}
+	return names, nil
+}
+
+func (g *gcsStorage) Delete(name string) error {
+	ctx := context.Background()
+	err := g.client.Bucket(g.bucket).Object(joinPrefix(g.prefix, name)).Delete(ctx)
+	if err == storage.ErrObjectNotExist {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,90 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package storage
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/colinmarc/hdfs/v2"
+)
+
+// hdfsStorage implements ExternalStorage against an HDFS namenode. Host is
+// the namenode address (host:port) and Path is the directory under which
+// backup objects are stored.
+type hdfsStorage struct {
+	client *hdfs.Client
+	dir    string
+}
+
+func newHDFSStorage(b *Backend) (ExternalStorage, error) {
+	addr := b.Host
+	if addr == "" {
+		return nil, fmt.Errorf("storage: hdfs backend requires a namenode host")
+	}
+
+	opts := hdfs.ClientOptions{Addresses: []string{addr}}
+	if user := b.Args.Get("user"); user != "" {
+		opts.User = user
+	}
+	client, err := hdfs.NewClient(opts)
+	if err != nil {
+		return nil, fmt.Errorf("storage: could not create hdfs client: %v", err)
+	}
+
+	dir := "/" + b.Path
+	if err := client.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &hdfsStorage{client: client, dir: dir}, nil
+}
+
+func (h *hdfsStorage) Open(name string) (io.ReadCloser, error) {
+	return h.client.Open(path.Join(h.dir, name))
+}
+
+func (h *hdfsStorage) Create(name string) (io.WriteCloser, error) {
+	p := path.Join(h.dir, name)
+	if err := h.client.MkdirAll(path.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	return h.client.Create(p)
+}
+
+func (h *hdfsStorage) List(prefix string) ([]string, error) {
+	entries, err := h.client.ReadDir(h.dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if prefix == "" || strings.HasPrefix(e.Name(), prefix) {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (h *hdfsStorage) Delete(name string) error {
+	err := h.client.Remove(path.Join(h.dir, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,48 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package storage
+
+import (
+	"os"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/Azure/azure-storage-blob-go/azblob"
+	minio "github.com/minio/minio-go"
+)
+
+// IsNotExist reports whether err from Open means the requested object simply
+// does not exist, across every ExternalStorage backend. Callers that treat a
+// missing sidecar object as "none was ever written" must check this rather
+// than assuming any Open error means that, so a transient or permission
+// error is not silently swallowed.
+func IsNotExist(err error) bool {
+	if err == nil {
+		return false
+	}
+	if os.IsNotExist(err) {
+		return true
+	}
+	if err == storage.ErrObjectNotExist {
+		return true
+	}
+	if resp := minio.ToErrorResponse(err); resp.Code == "NoSuchKey" {
+		return true
+	}
+	if serr, ok := err.(azblob.StorageError); ok {
+		return serr.ServiceCode() == azblob.ServiceCodeBlobNotFound
+	}
+	// The hdfs/v2 client does not always surface os.ErrNotExist in a way
+	// os.IsNotExist recognizes; fall back to matching its own error text.
+	return strings.Contains(err.Error(), "file does not exist")
+}
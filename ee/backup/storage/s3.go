@@ -0,0 +1,160 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	minio "github.com/minio/minio-go"
+	"github.com/minio/minio-go/pkg/credentials"
+)
+
+// s3Storage implements ExternalStorage against Amazon S3 and any
+// S3-compatible service (MinIO, on-prem gateways, etc). The "minio" scheme is
+// an alias for "s3" that simply implies a custom ?endpoint= is expected.
+type s3Storage struct {
+	client       *minio.Client
+	bucket       string
+	prefix       string
+	sse          string
+	storageClass string
+}
+
+func newS3Storage(b *Backend) (ExternalStorage, error) {
+	endpoint := b.Args.Get("endpoint")
+	if endpoint == "" {
+		endpoint = b.Host
+	}
+	if endpoint == "" {
+		return nil, fmt.Errorf("storage: s3 backend requires a host or ?endpoint=")
+	}
+
+	secure := b.Args.Get("secure") != "false"
+	client, err := minio.NewWithOptions(endpoint, &minio.Options{
+		Creds:  s3Credentials(b),
+		Secure: secure,
+		Region: b.Args.Get("region"),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: could not create s3 client: %v", err)
+	}
+
+	parts := splitBucketPrefix(b.Path)
+	return &s3Storage{
+		client:       client,
+		bucket:       parts[0],
+		prefix:       parts[1],
+		sse:          b.Args.Get("sse"),
+		storageClass: b.Args.Get("storage-class"),
+	}, nil
+}
+
+// s3Credentials resolves the credentials used to sign requests against the
+// bucket. A ?credentials-file= pointing at a standard AWS credentials INI
+// file (the same format and default profile handling as the AWS CLI/SDKs)
+// takes precedence; otherwise the usual AWS default chain is consulted so
+// that env vars, ~/.aws/credentials, and EC2/ECS instance roles all keep
+// working without requiring ?credentials-file= at all.
+func s3Credentials(b *Backend) *credentials.Credentials {
+	if cf := b.Args.Get("credentials-file"); cf != "" {
+		return credentials.NewFileAWSCredentials(cf, b.Args.Get("credentials-profile"))
+	}
+	return credentials.NewChainCredentials([]credentials.Provider{
+		&credentials.EnvAWS{},
+		&credentials.FileAWSCredentials{},
+		&credentials.IAM{Client: &http.Client{}},
+	})
+}
+
+func (s *s3Storage) Open(name string) (io.ReadCloser, error) {
+	obj, err := s.client.GetObject(s.bucket, joinPrefix(s.prefix, name), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject is lazy; Stat surfaces a missing object here rather than on
+	// the first Read, so callers can tell it apart with IsNotExist.
+	if _, err := obj.Stat(); err != nil {
+		_ = obj.Close()
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (s *s3Storage) Create(name string) (io.WriteCloser, error) {
+	opts := minio.PutObjectOptions{StorageClass: s.storageClass}
+	if s.sse != "" {
+		opts.ServerSideEncryption = minio.NewSSE()
+	}
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.client.PutObject(s.bucket, joinPrefix(s.prefix, name), pr, -1, opts)
+		_ = pr.CloseWithError(err)
+		done <- err
+	}()
+	return &s3Writer{PipeWriter: pw, done: done}, nil
+}
+
+// s3Writer wraps the pipe fed to a background PutObject call so that Close
+// waits for the upload to finish and surfaces its error, rather than
+// returning as soon as the pipe itself is drained.
+type s3Writer struct {
+	*io.PipeWriter
+	done chan error
+}
+
+func (w *s3Writer) Close() error {
+	if err := w.PipeWriter.Close(); err != nil {
+		return err
+	}
+	if err := <-w.done; err != nil {
+		return fmt.Errorf("storage: s3 upload failed: %v", err)
+	}
+	return nil
+}
+
+func (s *s3Storage) List(prefix string) ([]string, error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	var names []string
+	for obj := range s.client.ListObjects(s.bucket, joinPrefix(s.prefix, prefix), false, doneCh) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		names = append(names, strings.TrimPrefix(obj.Key, joinPrefix(s.prefix, "")))
+	}
+	return names, nil
+}
+
+func (s *s3Storage) Delete(name string) error {
+	return s.client.RemoveObject(s.bucket, joinPrefix(s.prefix, name))
+}
+
+func splitBucketPrefix(path string) [2]string {
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' {
+			return [2]string{path[:i], path[i+1:]}
+		}
+	}
+	return [2]string{path, ""}
+}
+
+func joinPrefix(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
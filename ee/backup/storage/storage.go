@@ -0,0 +1,100 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+// Package storage provides a pluggable abstraction over the object stores that
+// Dgraph backup and restore can read from and write to. A URI scheme (s3, minio,
+// gs, azure, hdfs, file) selects the concrete ExternalStorage implementation, and
+// backend-specific behavior (credentials, encryption, endpoints) is configured via
+// query arguments on that URI rather than environment variables or CLI flags.
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+)
+
+// ExternalStorage is the interface backup and restore use to read and write backup
+// objects, regardless of where they actually live. Implementations must be safe
+// for concurrent use by multiple goroutines.
+type ExternalStorage interface {
+	// Open returns a reader for the object at name, relative to the backend's
+	// configured path. It is the caller's responsibility to close it.
+	Open(name string) (io.ReadCloser, error)
+
+	// Create returns a writer for a new object at name, relative to the
+	// backend's configured path. It is the caller's responsibility to close it;
+	// closing commits the object.
+	Create(name string) (io.WriteCloser, error)
+
+	// List returns the names of all objects under prefix, relative to the
+	// backend's configured path.
+	List(prefix string) ([]string, error)
+
+	// Delete removes the object at name, relative to the backend's configured
+	// path. It is not an error to delete an object that does not exist.
+	Delete(name string) error
+}
+
+// Backend describes a parsed backup/restore destination: the scheme that picked
+// the ExternalStorage implementation, plus the pieces of the URI that
+// implementation needs to talk to the underlying service.
+type Backend struct {
+	Scheme  string
+	Host    string
+	Path    string
+	Args    url.Values
+	Storage ExternalStorage
+}
+
+// ParseBackend parses uri, of the form [scheme]://[host]/[path]?[args], and
+// returns a Backend whose Storage is ready to use. Supported schemes are
+// "s3", "minio", "gs", "azure", "hdfs" and "file" (the default when no scheme,
+// or a bare path, is given).
+func ParseBackend(uri string) (*Backend, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("storage: could not parse %q: %v", uri, err)
+	}
+
+	scheme := u.Scheme
+	if scheme == "" {
+		scheme = "file"
+	}
+
+	b := &Backend{
+		Scheme: scheme,
+		Host:   u.Host,
+		Path:   strings.TrimPrefix(u.Path, "/"),
+		Args:   u.Query(),
+	}
+
+	switch scheme {
+	case "s3", "minio":
+		b.Storage, err = newS3Storage(b)
+	case "gs":
+		b.Storage, err = newGCSStorage(b)
+	case "azure":
+		b.Storage, err = newAzureStorage(b)
+	case "hdfs":
+		b.Storage, err = newHDFSStorage(b)
+	case "file", "":
+		b.Storage, err = newFileStorage(b)
+	default:
+		return nil, fmt.Errorf("storage: unsupported scheme %q in %q", scheme, uri)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,102 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// aeadChunkWriter seals each frame it is given as one AEAD-encrypted chunk
+// and writes it to w, length-prefixed like any other backup chunk. Each
+// chunk's nonce is baseNonce with an incrementing big-endian counter folded
+// into its low 8 bytes, so no two chunks from the same object ever reuse a
+// nonce.
+type aeadChunkWriter struct {
+	w         io.Writer
+	aead      cipher.AEAD
+	baseNonce []byte
+	seq       uint64
+}
+
+func newAEADChunkWriter(w io.Writer, aead cipher.AEAD, baseNonce []byte) *aeadChunkWriter {
+	return &aeadChunkWriter{w: w, aead: aead, baseNonce: baseNonce}
+}
+
+// seal encrypts plaintext (a full backup chunk, e.g. the bytes writeKVList
+// would otherwise write) and writes it to the underlying writer as one frame.
+// It returns the number of bytes written to the underlying writer.
+func (e *aeadChunkWriter) seal(plaintext []byte) (int64, error) {
+	nonce := e.chunkNonce(e.seq)
+	e.seq++
+	ciphertext := e.aead.Seal(nil, nonce, plaintext, nil)
+	return writeFrame(e.w, ciphertext)
+}
+
+func (e *aeadChunkWriter) chunkNonce(seq uint64) []byte {
+	nonce := make([]byte, len(e.baseNonce))
+	copy(nonce, e.baseNonce)
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], binary.BigEndian.Uint64(nonce[len(nonce)-8:])^seq)
+	return nonce
+}
+
+// aeadChunkReader is the read-side counterpart of aeadChunkWriter: it reads
+// encrypted chunks from r, decrypts each, and presents their concatenated
+// plaintext through the io.Reader interface so it can be handed to
+// badger.DB.Load as if it were the original, unencrypted backup stream.
+type aeadChunkReader struct {
+	r         io.Reader
+	aead      cipher.AEAD
+	baseNonce []byte
+	seq       uint64
+	buf       bytes.Buffer
+}
+
+func newAEADChunkReader(r io.Reader, aead cipher.AEAD, baseNonce []byte) *aeadChunkReader {
+	return &aeadChunkReader{r: r, aead: aead, baseNonce: baseNonce}
+}
+
+func (d *aeadChunkReader) Read(p []byte) (int, error) {
+	if d.buf.Len() == 0 {
+		if err := d.fill(); err != nil {
+			return 0, err
+		}
+	}
+	return d.buf.Read(p)
+}
+
+func (d *aeadChunkReader) fill() error {
+	ciphertext, err := readFrame(d.r)
+	if err != nil {
+		return err
+	}
+
+	nonce := d.chunkNonce(d.seq)
+	d.seq++
+	plaintext, err := d.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return fmt.Errorf("backup: could not decrypt chunk %d: %v", d.seq-1, err)
+	}
+	d.buf.Write(plaintext)
+	return nil
+}
+
+func (d *aeadChunkReader) chunkNonce(seq uint64) []byte {
+	nonce := make([]byte, len(d.baseNonce))
+	copy(nonce, d.baseNonce)
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], binary.BigEndian.Uint64(nonce[len(nonce)-8:])^seq)
+	return nonce
+}
@@ -0,0 +1,160 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"hash/fnv"
+	"io"
+	"sync"
+
+	"github.com/dgraph-io/badger"
+	"github.com/dgraph-io/badger/pb"
+)
+
+// streamRestoreOptions configures how a backup stream is replayed into a
+// badger.StreamWriter.
+type streamRestoreOptions struct {
+	// Concurrency is the number of goroutines writing to the StreamWriter
+	// concurrently, each owning a disjoint partition of the keyspace.
+	Concurrency int
+	// MaxPendingWrites caps how many KV batches may be queued per partition
+	// before the decoder blocks, bounding memory use on a fast decode / slow
+	// disk restore.
+	MaxPendingWrites int
+	// ValueThreshold is the badger value-log threshold applied while
+	// restoring; larger values spend more disk on the value log and less on
+	// compaction of big values in the LSM tree.
+	ValueThreshold int64
+}
+
+// streamLoad decodes r's backup stream into pb.KVList batches and writes them
+// to a badger.StreamWriter over opts.Concurrency goroutines, each assigned a
+// fixed stream ID and a disjoint partition of the keyspace (so badger never
+// sees out-of-order versions for the same stream). progress, if non-nil, is
+// updated with bytes and keys written as they land.
+func streamLoad(db *badger.DB, r io.Reader, opts streamRestoreOptions, progress *progressReporter) error {
+	n := opts.Concurrency
+	if n < 1 {
+		n = 1
+	}
+	pending := opts.MaxPendingWrites
+	if pending < 1 {
+		pending = 1
+	}
+
+	sw := db.NewStreamWriter()
+	if err := sw.Prepare(); err != nil {
+		return err
+	}
+
+	partitions := make([]chan *pb.KVList, n)
+	for i := range partitions {
+		partitions[i] = make(chan *pb.KVList, pending)
+	}
+
+	// done is closed the first time a partition goroutine gives up after a
+	// failed sw.Write, so decodeInto stops blocking on channels nothing is
+	// draining anymore instead of deadlocking.
+	done := make(chan struct{})
+	var closeDone sync.Once
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(streamID uint32, ch <-chan *pb.KVList) {
+			defer wg.Done()
+			for list := range ch {
+				if err := sw.Write(list); err != nil {
+					errCh <- err
+					closeDone.Do(func() { close(done) })
+					return
+				}
+				if progress != nil {
+					progress.addKeys(int64(len(list.Kv)))
+					for _, kv := range list.Kv {
+						progress.addBytes(int64(len(kv.Key) + len(kv.Value)))
+					}
+				}
+			}
+		}(uint32(i+1), partitions[i])
+	}
+
+	readErr := decodeInto(r, partitions, n, done)
+
+	for _, ch := range partitions {
+		close(ch)
+	}
+	wg.Wait()
+
+	select {
+	case err := <-errCh:
+		return err
+	default:
+	}
+	if readErr != nil {
+		return readErr
+	}
+	return sw.Flush()
+}
+
+// decodeInto reads length-prefixed pb.KVList batches from r and, for each
+// one, regroups its keys by partitions[keyPartition(key, n)] and forwards one
+// batch per partition, tagged with that partition's stream ID so badger's
+// StreamWriter can apply partitions concurrently. It returns early, without
+// error, if done is closed: that signals a partition goroutine has already
+// given up after a failed write, so there's no point decoding further and
+// nothing left to drain the channel a send would otherwise block on.
+func decodeInto(r io.Reader, partitions []chan *pb.KVList, n int, done <-chan struct{}) error {
+	buckets := make([][]*pb.KV, n)
+	for {
+		list, err := readKVList(r)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		for i := range buckets {
+			buckets[i] = buckets[i][:0]
+		}
+		for _, kv := range list.Kv {
+			p := keyPartition(kv.Key, n)
+			kv.StreamId = uint32(p + 1)
+			buckets[p] = append(buckets[p], kv)
+		}
+		for p, kvs := range buckets {
+			if len(kvs) == 0 {
+				continue
+			}
+			// buckets[p] is reused next iteration, so hand the partition
+			// goroutine its own copy rather than a view into it.
+			batch := &pb.KVList{Kv: append([]*pb.KV(nil), kvs...)}
+			select {
+			case partitions[p] <- batch:
+			case <-done:
+				return nil
+			}
+		}
+	}
+}
+
+// keyPartition deterministically assigns key to one of n partitions, so the
+// same key always lands on the same StreamWriter goroutine and its versions
+// are never reordered across partitions.
+func keyPartition(key []byte, n int) int {
+	h := fnv.New32a()
+	_, _ = h.Write(key)
+	return int(h.Sum32() % uint32(n))
+}
@@ -0,0 +1,107 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/pb"
+)
+
+func TestKeyPartitionIsStable(t *testing.T) {
+	key := []byte("some-key")
+	first := keyPartition(key, 8)
+	for i := 0; i < 10; i++ {
+		if p := keyPartition(key, 8); p != first {
+			t.Fatalf("keyPartition(%q, 8) = %d on attempt %d, want %d", key, p, i, first)
+		}
+	}
+}
+
+// TestDecodeIntoBatchesPerPartition confirms a single decoded pb.KVList is
+// regrouped into at most one batch per partition, rather than one channel
+// send per key.
+func TestDecodeIntoBatchesPerPartition(t *testing.T) {
+	const n = 4
+	var buf bytes.Buffer
+	var list pb.KVList
+	for i := 0; i < 40; i++ {
+		list.Kv = append(list.Kv, &pb.KV{Key: []byte(fmt.Sprintf("key-%d", i))})
+	}
+	if _, err := writeKVList(&buf, &list); err != nil {
+		t.Fatalf("writeKVList: %v", err)
+	}
+
+	partitions := make([]chan *pb.KVList, n)
+	for i := range partitions {
+		partitions[i] = make(chan *pb.KVList, 8)
+	}
+	done := make(chan struct{})
+
+	if err := decodeInto(&buf, partitions, n, done); err != nil {
+		t.Fatalf("decodeInto: %v", err)
+	}
+	for _, ch := range partitions {
+		close(ch)
+	}
+
+	totalBatches, totalKeys := 0, 0
+	for _, ch := range partitions {
+		for batch := range ch {
+			totalBatches++
+			totalKeys += len(batch.Kv)
+		}
+	}
+	if totalKeys != 40 {
+		t.Fatalf("expected 40 keys delivered, got %d", totalKeys)
+	}
+	if totalBatches > n {
+		t.Fatalf("expected at most %d batches (one per partition), got %d", n, totalBatches)
+	}
+}
+
+// TestDecodeIntoStopsOnDone confirms decodeInto gives up instead of blocking
+// forever when a partition's channel is full and done is closed, as happens
+// once a partition goroutine has stopped draining its channel after a failed
+// sw.Write.
+func TestDecodeIntoStopsOnDone(t *testing.T) {
+	const n = 1
+	var buf bytes.Buffer
+	for i := 0; i < 3; i++ {
+		list := &pb.KVList{Kv: []*pb.KV{{Key: []byte(fmt.Sprintf("key-%d", i))}}}
+		if _, err := writeKVList(&buf, list); err != nil {
+			t.Fatalf("writeKVList: %v", err)
+		}
+	}
+
+	// An unbuffered, never-drained channel forces the first batch's send to
+	// block; done must be what breaks that block.
+	partitions := []chan *pb.KVList{make(chan *pb.KVList)}
+	done := make(chan struct{})
+	close(done)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- decodeInto(&buf, partitions, n, done) }()
+
+	select {
+	case err := <-errCh:
+		if err != nil {
+			t.Fatalf("decodeInto: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("decodeInto blocked instead of returning once done was closed")
+	}
+}
@@ -0,0 +1,99 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/dgraph-io/badger"
+)
+
+// hashingReader tees everything read through r into hash, and counts the
+// bytes seen, so a digest and length can be computed as a stream is consumed
+// without buffering it.
+type hashingReader struct {
+	r       io.Reader
+	hash    hash.Hash
+	byteLen int64
+}
+
+func newHashingReader(r io.Reader) *hashingReader {
+	h := sha256.New()
+	return &hashingReader{r: io.TeeReader(r, h), hash: h}
+}
+
+func (h *hashingReader) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	h.byteLen += int64(n)
+	return n, err
+}
+
+func (h *hashingReader) sha256() string {
+	return hex.EncodeToString(h.hash.Sum(nil))
+}
+
+// verifyChecksum compares want (the checksum recorded when entry was written,
+// or nil if none was) against the digest and byte length observed while
+// reading it, and the number of keys entry alone contributed to db. It
+// returns an error describing exactly what didn't match.
+func verifyChecksum(want *Checksum, got *hashingReader, db *badger.DB, entry ChainEntry) error {
+	if want == nil {
+		return nil
+	}
+	if got.sha256() != want.SHA256 {
+		return fmt.Errorf("sha256 mismatch for %q: backup recorded %s, restore observed %s",
+			entry.Name, want.SHA256, got.sha256())
+	}
+	if got.byteLen != want.ByteLen {
+		return fmt.Errorf("byte length mismatch for %q: backup recorded %d, restore observed %d",
+			entry.Name, want.ByteLen, got.byteLen)
+	}
+
+	// want.KeyCount is the number of keys entry's own file contributed, not
+	// the chain's cumulative total, so count only the keys whose version
+	// visible at entry.ReadTs is newer than the previous entry's ReadTs. This
+	// includes keys entry updated as well as keys it added.
+	count, err := countKeys(db, entry.ReadTs, entry.PrevReadTs)
+	if err != nil {
+		return fmt.Errorf("while counting keys for %q: %v", entry.Name, err)
+	}
+	if count < want.KeyCount {
+		return fmt.Errorf("key count mismatch for %q: backup recorded %d keys, restore has %d",
+			entry.Name, want.KeyCount, count)
+	}
+	return nil
+}
+
+// countKeys returns the number of distinct keys visible in db as of readTs
+// whose visible version is newer than sinceTs.
+func countKeys(db *badger.DB, readTs, sinceTs uint64) (int64, error) {
+	txn := db.NewTransactionAt(readTs, false)
+	defer txn.Discard()
+
+	iopt := badger.DefaultIteratorOptions
+	iopt.PrefetchValues = false
+	it := txn.NewIterator(iopt)
+	defer it.Close()
+
+	var count int64
+	for it.Rewind(); it.Valid(); it.Next() {
+		if it.Item().Version() > sinceTs {
+			count++
+		}
+	}
+	return count, nil
+}
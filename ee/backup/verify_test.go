@@ -0,0 +1,54 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifyChecksumNilWantSkipsVerification(t *testing.T) {
+	got := newHashingReader(strings.NewReader("anything"))
+	if err := verifyChecksum(nil, got, nil, ChainEntry{Name: "r10-g1.backup"}); err != nil {
+		t.Fatalf("expected no error when no checksum was recorded, got %v", err)
+	}
+}
+
+func TestVerifyChecksumSHA256Mismatch(t *testing.T) {
+	got := newHashingReader(strings.NewReader("restored bytes"))
+	_, _ = got.Read(make([]byte, 64))
+
+	want := &Checksum{SHA256: "not-the-real-digest"}
+	err := verifyChecksum(want, got, nil, ChainEntry{Name: "r10-g1.backup"})
+	if err == nil || !strings.Contains(err.Error(), "sha256 mismatch") {
+		t.Fatalf("expected a sha256 mismatch error, got %v", err)
+	}
+}
+
+func TestVerifyChecksumByteLengthMismatch(t *testing.T) {
+	content := "restored bytes"
+	got := newHashingReader(strings.NewReader(content))
+	buf := make([]byte, 64)
+	for {
+		n, err := got.Read(buf)
+		if n == 0 || err != nil {
+			break
+		}
+	}
+
+	want := &Checksum{SHA256: got.sha256(), ByteLen: got.byteLen + 1}
+	err := verifyChecksum(want, got, nil, ChainEntry{Name: "r10-g1.backup"})
+	if err == nil || !strings.Contains(err.Error(), "byte length mismatch") {
+		t.Fatalf("expected a byte length mismatch error, got %v", err)
+	}
+}
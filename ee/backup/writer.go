@@ -0,0 +1,176 @@
+// +build !oss
+
+/*
+ * Copyright 2018 Dgraph Labs, Inc. and Contributors
+ *
+ * Licensed under the Dgraph Community License (the "License"); you
+ * may not use this file except in compliance with the License. You
+ * may obtain a copy of the License at
+ *
+ *     https://github.com/dgraph-io/dgraph/blob/master/licenses/DCL.txt
+ */
+
+package backup
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/dgraph-io/badger/pb"
+	"github.com/dgraph-io/dgraph/ee/backup/encryption"
+	"github.com/dgraph-io/dgraph/ee/backup/storage"
+)
+
+// Writer streams a single group's backup object to location, and on Close
+// writes its manifest and checksum sidecars. Use Create to obtain one.
+type Writer struct {
+	w        io.WriteCloser
+	hash     hash.Hash
+	sink     io.Writer // w and hash together; the header (if any) and all data go through it
+	byteLen  int64
+	keyCount int64
+	storage  storage.ExternalStorage
+	name     string
+	manifest *Manifest
+	aeadW    *aeadChunkWriter // non-nil when encryption is enabled
+}
+
+// Create opens a Writer for a new backup object belonging to groupId, taken at
+// readTs. lastBackupTS is 0 for a full backup, or the readTs of the backup
+// this one is incremental against. If keyProvider is non-nil, the object is
+// encrypted with a fresh per-object AES-256-GCM data key wrapped by
+// keyProvider.
+func Create(
+	location string, readTs, lastBackupTS uint64, groupId int, keyProvider encryption.KeyProvider,
+) (*Writer, error) {
+	backend, err := storage.ParseBackend(location)
+	if err != nil {
+		return nil, err
+	}
+
+	name := fmt.Sprintf("r%d-g%d.backup", readTs, groupId)
+	w, err := backend.Storage.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("while creating %q at %q: %v", name, location, err)
+	}
+
+	writer := &Writer{
+		w:       w,
+		hash:    sha256.New(),
+		storage: backend.Storage,
+		name:    name,
+		manifest: &Manifest{
+			BackupTS:     readTs,
+			LastBackupTS: lastBackupTS,
+			GroupId:      groupId,
+			Files:        []string{name},
+		},
+	}
+	writer.sink = io.MultiWriter(writer.w, writer.hash)
+
+	if keyProvider != nil {
+		if err := writer.enableEncryption(keyProvider); err != nil {
+			return nil, err
+		}
+	}
+	return writer, nil
+}
+
+// enableEncryption generates a random AES-256-GCM data key, wraps it with
+// keyProvider, and writes the resulting header to the object before any data.
+func (w *Writer) enableEncryption(keyProvider encryption.KeyProvider) error {
+	dek := make([]byte, 32)
+	if _, err := rand.Read(dek); err != nil {
+		return err
+	}
+	block, err := aes.NewCipher(dek)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+	baseNonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(baseNonce); err != nil {
+		return err
+	}
+
+	wrappedKey, err := keyProvider.WrapKey(dek)
+	if err != nil {
+		return fmt.Errorf("while wrapping data key: %v", err)
+	}
+	n, err := writeHeader(w.sink, &encryptionHeader{
+		Algorithm:  encryptionAlgorithm,
+		WrappedKey: wrappedKey,
+		BaseNonce:  baseNonce,
+	})
+	if err != nil {
+		return err
+	}
+	w.byteLen += n
+
+	w.aeadW = newAEADChunkWriter(w.sink, aead, baseNonce)
+	return nil
+}
+
+// WriteKVList appends list to the backup object, updating the running
+// checksum and key count as it goes.
+func (w *Writer) WriteKVList(list *pb.KVList) error {
+	var n int64
+	var err error
+	if w.aeadW != nil {
+		n, err = w.writeEncryptedKVList(list)
+	} else {
+		n, err = writeKVList(w.sink, list)
+	}
+	if err != nil {
+		return err
+	}
+	w.byteLen += n
+	w.keyCount += int64(len(list.Kv))
+	return nil
+}
+
+func (w *Writer) writeEncryptedKVList(list *pb.KVList) (int64, error) {
+	buf, err := list.Marshal()
+	if err != nil {
+		return 0, err
+	}
+	var inner bytes.Buffer
+	if _, err := writeFrame(&inner, buf); err != nil {
+		return 0, err
+	}
+	return w.aeadW.seal(inner.Bytes())
+}
+
+// Close commits the backup object and writes its manifest and checksum
+// sidecars.
+func (w *Writer) Close() error {
+	if err := w.w.Close(); err != nil {
+		return err
+	}
+	if err := writeManifest(w.storage, w.name, w.manifest); err != nil {
+		return err
+	}
+	if err := writeChecksum(w.storage, w.name, &Checksum{
+		SHA256:   hex.EncodeToString(w.hash.Sum(nil)),
+		ByteLen:  w.byteLen,
+		KeyCount: w.keyCount,
+	}); err != nil {
+		return err
+	}
+	return publishLatest(w.storage, BackupFile{
+		Name:         w.name,
+		GroupId:      w.manifest.GroupId,
+		ReadTs:       w.manifest.BackupTS,
+		LastBackupTS: w.manifest.LastBackupTS,
+	})
+}